@@ -0,0 +1,125 @@
+package range2cidr
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// addr128 represents a 128-bit IP address as a pair of uint64s, the same
+// split net/netip uses internally.  It exists purely as a fast internal
+// substitute for looping over [16]byte one byte at a time; Cmp, Add,
+// GetBit, and SetBit are kept as thin wrappers over it for callers that
+// still want the [16]byte form.
+type addr128 struct {
+	hi, lo uint64
+}
+
+func from16(b [16]byte) addr128 {
+	return addr128{
+		hi: binary.BigEndian.Uint64(b[0:8]),
+		lo: binary.BigEndian.Uint64(b[8:16]),
+	}
+}
+
+func (a addr128) to16() (ret [16]byte) {
+	binary.BigEndian.PutUint64(ret[0:8], a.hi)
+	binary.BigEndian.PutUint64(ret[8:16], a.lo)
+	return ret
+}
+
+// cmp returns 0 if a == b, -1 if a < b, and +1 if a > b.
+func (a addr128) cmp(b addr128) int {
+	switch {
+	case a.hi != b.hi:
+		if a.hi < b.hi {
+			return -1
+		}
+		return 1
+	case a.lo != b.lo:
+		if a.lo < b.lo {
+			return -1
+		}
+		return 1
+	default:
+		return 0
+	}
+}
+
+// add returns a+b and the carry out of the top bit.
+func (a addr128) add(b addr128) (ret addr128, carry uint64) {
+	var c0 uint64
+	ret.lo, c0 = bits.Add64(a.lo, b.lo, 0)
+	ret.hi, carry = bits.Add64(a.hi, b.hi, c0)
+	return ret, carry
+}
+
+// sub returns a-b and the borrow out of the top bit.
+func (a addr128) sub(b addr128) (ret addr128, borrow uint64) {
+	var b0 uint64
+	ret.lo, b0 = bits.Sub64(a.lo, b.lo, 0)
+	ret.hi, borrow = bits.Sub64(a.hi, b.hi, b0)
+	return ret, borrow
+}
+
+// bit returns the n-th bit of a, counting up from the least significant
+// bit (n == 0) of the full 128-bit value.
+func (a addr128) bit(n uint) bool {
+	switch {
+	case n < 64:
+		return (a.lo & (1 << n)) != 0
+	case n < 128:
+		return (a.hi & (1 << (n - 64))) != 0
+	default:
+		return false
+	}
+}
+
+// setBit returns a copy of a with its n-th bit (counting as in bit) set
+// or cleared.
+func (a addr128) setBit(n uint, set bool) addr128 {
+	switch {
+	case n < 64:
+		if set {
+			a.lo |= 1 << n
+		} else {
+			a.lo &^= 1 << n
+		}
+	case n < 128:
+		nn := n - 64
+		if set {
+			a.hi |= 1 << nn
+		} else {
+			a.hi &^= 1 << nn
+		}
+	}
+	return a
+}
+
+// trailingZeros returns the number of trailing zero bits in a, or 128 if
+// a is zero.
+func (a addr128) trailingZeros() uint {
+	if a.lo != 0 {
+		return uint(bits.TrailingZeros64(a.lo))
+	}
+	if a.hi != 0 {
+		return 64 + uint(bits.TrailingZeros64(a.hi))
+	}
+	return 128
+}
+
+// bitLen returns the minimum number of bits required to represent a, or
+// 0 if a is zero.
+func (a addr128) bitLen() int {
+	if a.hi != 0 {
+		return 64 + bits.Len64(a.hi)
+	}
+	return bits.Len64(a.lo)
+}
+
+// commonPrefixLen returns the number of leading bits a and b share.
+func (a addr128) commonPrefixLen(b addr128) int {
+	if x := a.hi ^ b.hi; x != 0 {
+		return bits.LeadingZeros64(x)
+	}
+	return 64 + bits.LeadingZeros64(a.lo^b.lo)
+}
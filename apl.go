@@ -0,0 +1,196 @@
+package range2cidr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+)
+
+// APLItem is one address prefix list item, as defined by RFC 3123 (the
+// DNS APL resource record).
+type APLItem struct {
+	Prefix netip.Prefix
+	Negate bool
+}
+
+// EncodeAPL serializes items to the RFC 3123 APL wire format: each item
+// is a 2-byte address family (1 = IPv4, 2 = IPv6), a 1-byte prefix
+// length, a 1-byte header (top bit = negation flag, low 7 bits =
+// AFDLENGTH), followed by AFDLENGTH address bytes with trailing zero
+// bytes stripped.
+func EncodeAPL(items []APLItem) ([]byte, error) {
+	var buf bytes.Buffer
+	for i, it := range items {
+		if err := encodeAPLItem(&buf, it); err != nil {
+			return nil, fmt.Errorf("APL: item %d: %w", i, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeAPLItem(buf *bytes.Buffer, it APLItem) error {
+
+	addr := it.Prefix.Addr()
+
+	var family uint16
+	var full []byte
+	switch {
+	case addr.Is4() || addr.Is4In6():
+		family = 1
+		a4 := addr.Unmap().As4()
+		full = a4[:]
+	case addr.Is6():
+		family = 2
+		a16 := addr.As16()
+		full = a16[:]
+	default:
+		return fmt.Errorf("invalid prefix address %v", it.Prefix)
+	}
+
+	pfxLen := it.Prefix.Bits()
+	if pfxLen < 0 || pfxLen > len(full)*8 {
+		return fmt.Errorf("invalid prefix length %d for a %d-byte address", pfxLen, len(full))
+	}
+
+	afdLen := len(full)
+	for afdLen > 0 && full[afdLen-1] == 0 {
+		afdLen--
+	}
+	if afdLen > 0x7F {
+		return fmt.Errorf("AFDLENGTH %d exceeds 7 bits", afdLen)
+	}
+
+	hdr := byte(afdLen)
+	if it.Negate {
+		hdr |= 0x80
+	}
+
+	var rec [4]byte
+	binary.BigEndian.PutUint16(rec[:2], family)
+	rec[2] = byte(pfxLen)
+	rec[3] = hdr
+
+	buf.Write(rec[:])
+	buf.Write(full[:afdLen])
+	return nil
+}
+
+// DecodeAPL parses the RFC 3123 APL wire format produced by EncodeAPL.
+// Addresses are right-padded with zeros back to their full 4- or
+// 16-byte length; it is an error for any bit beyond the stated prefix
+// length to be set.
+func DecodeAPL(b []byte) ([]APLItem, error) {
+
+	var RET []APLItem
+	for len(b) > 0 {
+
+		if len(b) < 4 {
+			return nil, fmt.Errorf("APL: truncated item header")
+		}
+
+		family := binary.BigEndian.Uint16(b[0:2])
+		pfxLen := int(b[2])
+		hdr := b[3]
+		negate := hdr&0x80 != 0
+		afdLen := int(hdr & 0x7F)
+		b = b[4:]
+
+		var addrLen int
+		switch family {
+		case 1:
+			addrLen = 4
+		case 2:
+			addrLen = 16
+		default:
+			return nil, fmt.Errorf("APL: unknown address family %d", family)
+		}
+
+		if afdLen > addrLen {
+			return nil, fmt.Errorf("APL: AFDLENGTH %d exceeds %d-byte address", afdLen, addrLen)
+		}
+		if len(b) < afdLen {
+			return nil, fmt.Errorf("APL: truncated address data")
+		}
+		if pfxLen < 0 || pfxLen > addrLen*8 {
+			return nil, fmt.Errorf("APL: invalid prefix length %d", pfxLen)
+		}
+
+		full := make([]byte, addrLen)
+		copy(full, b[:afdLen])
+		b = b[afdLen:]
+
+		if err := checkTrailingBitsZero(full, pfxLen); err != nil {
+			return nil, err
+		}
+
+		var addr netip.Addr
+		switch family {
+		case 1:
+			addr = netip.AddrFrom4([4]byte(full))
+		case 2:
+			addr = netip.AddrFrom16([16]byte(full))
+		}
+
+		RET = append(RET, APLItem{
+			Prefix: netip.PrefixFrom(addr, pfxLen),
+			Negate: negate,
+		})
+	}
+
+	return RET, nil
+}
+
+// checkTrailingBitsZero reports an error if full has any bit set at or
+// beyond pfxLen.
+func checkTrailingBitsZero(full []byte, pfxLen int) error {
+	for i := pfxLen; i < len(full)*8; i++ {
+		byteIx := i / 8
+		bitIx := 7 - (i % 8)
+		if full[byteIx]&(1<<bitIx) != 0 {
+			return fmt.Errorf("APL: address has bits set beyond prefix length %d", pfxLen)
+		}
+	}
+	return nil
+}
+
+// ToAPL converts the set to RFC 3123 APL items, via Deaggregate over its
+// positive ranges.  When the set has holes relative to its bounding
+// prefixes (the shape Remove/Complement tend to leave behind), and
+// describing those holes as negated items is more compact than listing
+// every positive range directly, ToAPL prefers the compact form.
+func (s *IPSet) ToAPL() []APLItem {
+	var RET []APLItem
+	v4, v6 := partitionByFamily(s.ranges)
+	if len(v4) > 0 {
+		RET = append(RET, aplEncodeFamily(v4)...)
+	}
+	if len(v6) > 0 {
+		RET = append(RET, aplEncodeFamily(v6)...)
+	}
+	return RET
+}
+
+func aplEncodeFamily(ranges []Range) []APLItem {
+
+	direct := aplItemsFromRanges(ranges, false)
+
+	cover := Range{A: ranges[0].A, Z: ranges[len(ranges)-1].Z}
+	holes := subtractRanges([]Range{cover}, ranges)
+	alt := append(aplItemsFromRanges([]Range{cover}, false), aplItemsFromRanges(holes, true)...)
+
+	if len(alt) < len(direct) {
+		return alt
+	}
+	return direct
+}
+
+func aplItemsFromRanges(ranges []Range, negate bool) []APLItem {
+	var RET []APLItem
+	for _, r := range ranges {
+		for _, pfx := range r.Deaggregate() {
+			RET = append(RET, APLItem{Prefix: pfx, Negate: negate})
+		}
+	}
+	return RET
+}
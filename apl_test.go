@@ -0,0 +1,86 @@
+package range2cidr
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestAPLRoundTrip(t *testing.T) {
+
+	t.Log("APL ENCODE / DECODE ROUND TRIP:")
+
+	items := []APLItem{
+		{Prefix: netip.MustParsePrefix("10.0.0.0/8"), Negate: false},
+		{Prefix: netip.MustParsePrefix("10.1.0.0/16"), Negate: true},
+		{Prefix: netip.MustParsePrefix("2001:db8::/32"), Negate: false},
+		{Prefix: netip.MustParsePrefix("::/0"), Negate: true},
+		{Prefix: netip.MustParsePrefix("0.0.0.0/0"), Negate: false},
+	}
+
+	enc, err := EncodeAPL(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec, err := DecodeAPL(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(items, dec) {
+		t.Fatalf("roundtrip mismatch:\n got %#v\nwant %#v", dec, items)
+	}
+
+	t.Log("\t", cmsg(true, "SUCCESS!"))
+}
+
+func TestAPLRejectsBitsBeyondPrefix(t *testing.T) {
+	// v4, /24 prefix length, but AFDLENGTH 4 (full address) with a set
+	// low byte, which falls outside the stated /24.
+	rec := []byte{0, 1, 24, 4, 10, 0, 0, 1}
+	if _, err := DecodeAPL(rec); err == nil {
+		t.Fatal("expected an error for bits set beyond the prefix length")
+	}
+}
+
+func TestAPLStripsTrailingZeroBytes(t *testing.T) {
+
+	items := []APLItem{{Prefix: netip.MustParsePrefix("10.0.0.0/8"), Negate: false}}
+	enc, err := EncodeAPL(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// family(2) + pfxlen(1) + hdr(1) + AFDLENGTH 1 byte ("10")
+	if len(enc) != 5 {
+		t.Fatalf("expected trailing zero bytes stripped down to 1, got %d bytes: %x", len(enc), enc)
+	}
+}
+
+func TestIPSetToAPL(t *testing.T) {
+
+	t.Log("IP SET -> APL -> IP SET ROUND TRIP:")
+
+	var b IPSetBuilder
+	b.AddPrefix(netip.MustParsePrefix("10.0.0.0/8"))
+	b.RemovePrefix(netip.MustParsePrefix("10.1.0.0/16"))
+	s := b.IPSet()
+
+	items := s.ToAPL()
+
+	var got IPSetBuilder
+	for _, it := range items {
+		if it.Negate {
+			got.RemovePrefix(it.Prefix)
+		} else {
+			got.AddPrefix(it.Prefix)
+		}
+	}
+
+	if !reflect.DeepEqual(got.IPSet().Ranges(), s.Ranges()) {
+		t.Fatalf("APL round trip via IPSetBuilder mismatch:\n got %+v\nwant %+v", got.IPSet().Ranges(), s.Ranges())
+	}
+
+	t.Log("\t", cmsg(true, "SUCCESS!"))
+}
@@ -0,0 +1,51 @@
+package range2cidr
+
+import (
+	"math/rand"
+	"net/netip"
+	"testing"
+)
+
+// buildRandomRanges generates n deterministic pseudo-random IPv4 ranges,
+// sized up to a /16, for use as aggregation benchmark input.
+func buildRandomRanges(n int) []Range {
+	rng := rand.New(rand.NewSource(1))
+	sR := make([]Range, n)
+	for i := range sR {
+		a := rng.Uint32()
+		nBits := uint(rng.Intn(16))
+		z := a + (uint32(1) << nBits) - 1
+		if z < a {
+			z = 0xFFFFFFFF
+		}
+		sR[i] = RangeFromAddrs(Uint32ToV4(a), Uint32ToV4(z))
+	}
+	return sR
+}
+
+func BenchmarkAggregate100k(b *testing.B) {
+
+	base := buildRandomRanges(100_000)
+	buf := make([]Range, len(base))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		copy(buf, base)
+		b.StartTimer()
+
+		Aggregate(buf)
+	}
+}
+
+func BenchmarkDeaggregateFullV4(b *testing.B) {
+
+	lo := netip.MustParseAddr("0.0.0.1")
+	hi := netip.MustParseAddr("255.255.255.254")
+	rng := RangeFromAddrs(lo, hi)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rng.Deaggregate()
+	}
+}
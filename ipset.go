@@ -0,0 +1,265 @@
+package range2cidr
+
+import (
+	"net/netip"
+	"sort"
+)
+
+// IPSet is an immutable, canonicalized set of IP addresses, represented
+// internally as a sorted list of disjoint Range values.
+//
+// Build one with an IPSetBuilder.
+type IPSet struct {
+	ranges []Range
+}
+
+// IPSetBuilder accumulates Add/Remove operations and resolves them into
+// an IPSet on demand.  The zero value is ready to use.
+type IPSetBuilder struct {
+	adds []Range
+	dels []Range
+}
+
+// AddRange adds all addresses in r to the set under construction.
+func (b *IPSetBuilder) AddRange(r Range) {
+	r.Normalize()
+	b.adds = append(b.adds, r)
+}
+
+// AddPrefix adds all addresses covered by p to the set under construction.
+func (b *IPSetBuilder) AddPrefix(p netip.Prefix) {
+	b.AddRange(RangeFromPrefix(p))
+}
+
+// AddAddr adds a single address to the set under construction.
+func (b *IPSetBuilder) AddAddr(a netip.Addr) {
+	b.AddRange(RangeFromAddrs(a, a))
+}
+
+// RemoveRange removes all addresses in r from the set under construction.
+func (b *IPSetBuilder) RemoveRange(r Range) {
+	r.Normalize()
+	b.dels = append(b.dels, r)
+}
+
+// RemovePrefix removes all addresses covered by p from the set under
+// construction.
+func (b *IPSetBuilder) RemovePrefix(p netip.Prefix) {
+	b.RemoveRange(RangeFromPrefix(p))
+}
+
+// RemoveAddr removes a single address from the set under construction.
+func (b *IPSetBuilder) RemoveAddr(a netip.Addr) {
+	b.RemoveRange(RangeFromAddrs(a, a))
+}
+
+// Complement replaces the set under construction with its complement,
+// bounded to the whole IPv4 address space plus the whole IPv6 address
+// space (the union of the two, not the full 128-bit address space, so
+// the IPv4-mapped ::ffff:0:0/96 block is never double-counted).
+func (b *IPSetBuilder) Complement() {
+
+	snap := b.IPSet()
+	v4Snap, v6Snap := partitionByFamily(snap.ranges)
+
+	v4Comp := subtractRanges([]Range{v4FullRange()}, v4Snap)
+	v6Comp := subtractRanges(v6FullRangeExcl4(), v6Snap)
+
+	b.adds = append(v4Comp, v6Comp...)
+	b.dels = nil
+}
+
+// IPSet resolves the accumulated Add/Remove operations into an immutable,
+// canonicalized IPSet.
+func (b *IPSetBuilder) IPSet() *IPSet {
+	adds := Aggregate(append([]Range(nil), b.adds...))
+	dels := Aggregate(append([]Range(nil), b.dels...))
+	return &IPSet{ranges: Aggregate(subtractRanges(adds, dels))}
+}
+
+// Ranges returns the set's covering ranges, sorted and disjoint.
+func (s *IPSet) Ranges() []Range {
+	return append([]Range(nil), s.ranges...)
+}
+
+// Prefixes returns the set's covering network prefixes, computed by
+// running Deaggregate over each range.
+func (s *IPSet) Prefixes() []netip.Prefix {
+	var RET []netip.Prefix
+	for _, r := range s.ranges {
+		RET = append(RET, r.Deaggregate()...)
+	}
+	return RET
+}
+
+// Contains reports whether addr is a member of the set.
+func (s *IPSet) Contains(addr netip.Addr) bool {
+	bs := addr.As16()
+	i := sort.Search(len(s.ranges), func(i int) bool {
+		return Cmp(&s.ranges[i].Z, &bs) >= 0
+	})
+	return i < len(s.ranges) && Cmp(&s.ranges[i].A, &bs) <= 0
+}
+
+// ContainsPrefix reports whether every address covered by p is a member
+// of the set.
+func (s *IPSet) ContainsPrefix(p netip.Prefix) bool {
+	r := RangeFromPrefix(p)
+	i := sort.Search(len(s.ranges), func(i int) bool {
+		return Cmp(&s.ranges[i].Z, &r.A) >= 0
+	})
+	return i < len(s.ranges) &&
+		Cmp(&s.ranges[i].A, &r.A) <= 0 &&
+		Cmp(&s.ranges[i].Z, &r.Z) >= 0
+}
+
+// Overlaps reports whether s and o share any address.
+func (s *IPSet) Overlaps(o *IPSet) bool {
+	i, j := 0, 0
+	for i < len(s.ranges) && j < len(o.ranges) {
+		a, b := s.ranges[i], o.ranges[j]
+		if Cmp(&a.Z, &b.A) < 0 {
+			i++
+		} else if Cmp(&b.Z, &a.A) < 0 {
+			j++
+		} else {
+			return true
+		}
+	}
+	return false
+}
+
+// Union returns the set of addresses in s, o, or both.
+func (s *IPSet) Union(o *IPSet) *IPSet {
+	merged := append(append([]Range(nil), s.ranges...), o.ranges...)
+	return &IPSet{ranges: Aggregate(merged)}
+}
+
+// Intersect returns the set of addresses present in both s and o.
+func (s *IPSet) Intersect(o *IPSet) *IPSet {
+	return &IPSet{ranges: intersectRanges(s.ranges, o.ranges)}
+}
+
+// Difference returns the set of addresses in s that are not in o.
+func (s *IPSet) Difference(o *IPSet) *IPSet {
+	return &IPSet{ranges: subtractRanges(s.ranges, o.ranges)}
+}
+
+// intersectRanges returns the addresses present in both a and b, where
+// both slices are already sorted and internally disjoint (as produced by
+// Aggregate).
+func intersectRanges(a, b []Range) []Range {
+	var RET []Range
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		ra, rb := a[i], b[j]
+
+		lo := ra.A
+		if Cmp(&rb.A, &lo) > 0 {
+			lo = rb.A
+		}
+		hi := ra.Z
+		if Cmp(&rb.Z, &hi) < 0 {
+			hi = rb.Z
+		}
+		if Cmp(&lo, &hi) <= 0 {
+			RET = append(RET, Range{A: lo, Z: hi})
+		}
+
+		if Cmp(&ra.Z, &rb.Z) < 0 {
+			i++
+		} else {
+			j++
+		}
+	}
+	return RET
+}
+
+// subtractRanges removes rems from adds, where both slices are already
+// sorted and internally disjoint (as produced by Aggregate).  Each add
+// range is split into at most two remainder ranges per overlapping rem
+// range: the span below it and the span above it.
+func subtractRanges(adds, rems []Range) []Range {
+
+	RET := make([]Range, 0, len(adds))
+	var one [16]byte
+	SetBit(&one, 0, true)
+
+	j := 0
+	for _, a := range adds {
+		cur := a
+		consumed := false
+
+		k := j
+		for k < len(rems) {
+			rm := rems[k]
+			if Cmp(&rm.A, &cur.Z) > 0 {
+				break
+			}
+			if Cmp(&rm.Z, &cur.A) < 0 {
+				k++
+				continue
+			}
+
+			// remainder below rm, if any
+			if Cmp(&rm.A, &cur.A) > 0 {
+				RET = append(RET, Range{A: cur.A, Z: decrement(rm.A)})
+			}
+
+			if Cmp(&rm.Z, &cur.Z) >= 0 {
+				consumed = true
+				break
+			}
+
+			cur.A, _ = Add(&rm.Z, &one)
+			k++
+		}
+		j = k
+
+		if !consumed {
+			RET = append(RET, cur)
+		}
+	}
+
+	return RET
+}
+
+// decrement returns a - 1, treating a as a 128-bit big-endian integer.
+func decrement(a [16]byte) [16]byte {
+	for i := 15; i >= 0; i-- {
+		if a[i] > 0 {
+			a[i]--
+			break
+		}
+		a[i] = 0xFF
+	}
+	return a
+}
+
+// partitionByFamily splits ranges into their IPv4 and IPv6 portions.  A
+// range that straddles the ::ffff:0:0/96 boundary (its start and end
+// addresses are numerically adjacent across it, even though one
+// represents an IPv4 address and the other doesn't) is clipped rather
+// than bucketed by its start address alone, so the two returned slices
+// never contain a range that's part v4, part v6.
+func partitionByFamily(ranges []Range) (v4, v6 []Range) {
+	v4 = intersectRanges(ranges, []Range{v4FullRange()})
+	for _, part := range v6FullRangeExcl4() {
+		v6 = append(v6, intersectRanges(ranges, []Range{part})...)
+	}
+	return v4, v6
+}
+
+// v4FullRange returns a Range covering the entire IPv4 address space, in
+// its IPv4-mapped IPv6 representation.
+func v4FullRange() Range {
+	return RangeFromPrefix(netip.MustParsePrefix("0.0.0.0/0"))
+}
+
+// v6FullRangeExcl4 returns the entire IPv6 address space, split around
+// the IPv4-mapped block (::ffff:0:0/96) so it never overlaps a v4 range.
+func v6FullRangeExcl4() []Range {
+	full := RangeFromPrefix(netip.MustParsePrefix("::/0"))
+	v4Mapped := RangeFromPrefix(netip.MustParsePrefix("::ffff:0:0/96"))
+	return subtractRanges([]Range{full}, []Range{v4Mapped})
+}
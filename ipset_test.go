@@ -0,0 +1,130 @@
+package range2cidr
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestIPSetBuilder(t *testing.T) {
+
+	t.Log("IP SET BUILD / CONTAINS / HOLES:")
+
+	var b IPSetBuilder
+	b.AddPrefix(netip.MustParsePrefix("10.0.0.0/8"))
+	b.RemovePrefix(netip.MustParsePrefix("10.1.0.0/16"))
+	s := b.IPSet()
+
+	cases := []struct {
+		addr string
+		want bool
+	}{
+		{"10.0.0.1", true},
+		{"10.1.0.5", false},
+		{"10.2.0.5", true},
+	}
+
+	for _, c := range cases {
+		got := s.Contains(netip.MustParseAddr(c.addr))
+		if got != c.want {
+			t.Log("\t", cmsg(false, "MISMATCH"), c.addr, got, c.want)
+			t.FailNow()
+		}
+		t.Log("\t", cmsg(true, "OK"), c.addr)
+	}
+
+	if s.ContainsPrefix(netip.MustParsePrefix("10.0.0.0/8")) {
+		t.Fatal("10.0.0.0/8 should not be fully contained once a hole was removed")
+	}
+	if !s.ContainsPrefix(netip.MustParsePrefix("10.0.0.0/16")) {
+		t.Fatal("expected 10.0.0.0/16 to be fully contained")
+	}
+
+	t.Log("\t", cmsg(true, "SUCCESS!"))
+}
+
+func TestIPSetAlgebra(t *testing.T) {
+
+	t.Log("IP SET UNION / INTERSECT / DIFFERENCE / OVERLAPS:")
+
+	var bA, bB IPSetBuilder
+	bA.AddPrefix(netip.MustParsePrefix("10.0.0.0/16"))
+	bB.AddPrefix(netip.MustParsePrefix("10.1.0.0/16"))
+	sA, sB := bA.IPSet(), bB.IPSet()
+
+	if sA.Overlaps(sB) {
+		t.Fatal("10.0.0.0/16 and 10.1.0.0/16 should not overlap")
+	}
+
+	union := sA.Union(sB)
+	if !union.ContainsPrefix(netip.MustParsePrefix("10.0.0.0/15")) {
+		t.Fatal("union of adjacent /16s should aggregate into a /15")
+	}
+
+	diff := union.Difference(sB)
+	if diff.Contains(netip.MustParseAddr("10.1.0.1")) {
+		t.Fatal("difference should have removed 10.1.0.0/16")
+	}
+
+	inter := union.Intersect(sA)
+	if !inter.ContainsPrefix(netip.MustParsePrefix("10.0.0.0/16")) {
+		t.Fatal("intersect of union with sA should still cover sA")
+	}
+
+	t.Log("\t", cmsg(true, "SUCCESS!"))
+}
+
+func TestIPSetComplementAcrossV4MappedBoundary(t *testing.T) {
+
+	t.Log("IP SET COMPLEMENT: range straddling the ::ffff:0:0/96 boundary:")
+
+	// ::fffe:ffff:fffd is 3 below ::ffff:0:0, so this range is numerically
+	// contiguous with the IPv4-mapped block despite starting in plain IPv6
+	// space.
+	straddle := Range{
+		A: netip.MustParseAddr("::fffe:ffff:fffd").As16(),
+		Z: netip.MustParseAddr("::ffff:0.0.0.10").As16(),
+	}
+
+	var b IPSetBuilder
+	b.AddRange(straddle)
+	s := b.IPSet()
+	if !s.Contains(netip.MustParseAddr("0.0.0.10")) {
+		t.Fatal("expected 0.0.0.10 to be in the set before complementing")
+	}
+
+	b.Complement()
+	comp := b.IPSet()
+	if comp.Contains(netip.MustParseAddr("0.0.0.10")) {
+		t.Fatal("complement should not contain 0.0.0.10: it was in the original set")
+	}
+	if comp.Contains(netip.MustParseAddr("::fffe:ffff:fffe")) {
+		t.Fatal("complement should not contain an address from the original v6 span")
+	}
+	if !comp.Contains(netip.MustParseAddr("0.0.0.11")) {
+		t.Fatal("complement should contain v4 addresses outside the straddling range")
+	}
+
+	t.Log("\t", cmsg(true, "SUCCESS!"))
+}
+
+func TestIPSetComplement(t *testing.T) {
+
+	t.Log("IP SET COMPLEMENT:")
+
+	var b IPSetBuilder
+	b.AddPrefix(netip.MustParsePrefix("10.0.0.0/8"))
+	b.Complement()
+	s := b.IPSet()
+
+	if s.Contains(netip.MustParseAddr("10.0.0.1")) {
+		t.Fatal("complement should not contain 10.0.0.1")
+	}
+	if !s.Contains(netip.MustParseAddr("11.0.0.1")) {
+		t.Fatal("complement should contain addresses outside the removed /8")
+	}
+	if !s.Contains(netip.MustParseAddr("::1")) {
+		t.Fatal("complement is bounded by the full IPv4+IPv6 union, so untouched IPv6 space remains in")
+	}
+
+	t.Log("\t", cmsg(true, "SUCCESS!"))
+}
@@ -0,0 +1,83 @@
+package range2cidr
+
+import (
+	"net/netip"
+	"sort"
+)
+
+// Lookup is a compiled, read-only membership index over a set of
+// ranges, built once via NewLookup and then queried in O(log n) time.
+type Lookup struct {
+	v4Start, v4End []uint64
+	v4Ranges       []Range
+
+	v6Start, v6End []addr128
+	v6Ranges       []Range
+}
+
+// NewLookup normalizes and aggregates sR, then compiles it into a
+// Lookup.  sR is not mutated; Aggregate is run over a copy.
+func NewLookup(sR []Range) *Lookup {
+
+	cp := append([]Range(nil), sR...)
+	agg := Aggregate(cp)
+	v4, v6 := partitionByFamily(agg)
+
+	l := &Lookup{}
+	for _, r := range v4 {
+		a4, _ := V4ToUint32(netip.AddrFrom16(r.A))
+		z4, _ := V4ToUint32(netip.AddrFrom16(r.Z))
+		l.v4Start = append(l.v4Start, uint64(a4))
+		l.v4End = append(l.v4End, uint64(z4))
+		l.v4Ranges = append(l.v4Ranges, r)
+	}
+	for _, r := range v6 {
+		l.v6Start = append(l.v6Start, from16(r.A))
+		l.v6End = append(l.v6End, from16(r.Z))
+		l.v6Ranges = append(l.v6Ranges, r)
+	}
+
+	return l
+}
+
+// Contains reports whether addr falls within one of the compiled
+// ranges.
+func (l *Lookup) Contains(addr netip.Addr) bool {
+	_, ok := l.Find(addr)
+	return ok
+}
+
+// ContainsPrefix reports whether every address covered by p falls
+// within a single one of the compiled ranges.
+func (l *Lookup) ContainsPrefix(p netip.Prefix) bool {
+	r := RangeFromPrefix(p)
+	found, ok := l.Find(netip.AddrFrom16(r.A))
+	if !ok {
+		return false
+	}
+	return Cmp(&found.Z, &r.Z) >= 0
+}
+
+// Find returns the compiled range containing addr, if any.
+func (l *Lookup) Find(addr netip.Addr) (Range, bool) {
+
+	if addr.Is4() || addr.Is4In6() {
+		v, _ := V4ToUint32(addr)
+		i := sort.Search(len(l.v4Start), func(i int) bool {
+			return l.v4Start[i] > uint64(v)
+		}) - 1
+		if i >= 0 && uint64(v) <= l.v4End[i] {
+			return l.v4Ranges[i], true
+		}
+		return Range{}, false
+	}
+
+	a := from16(addr.As16())
+	i := sort.Search(len(l.v6Start), func(i int) bool {
+		return l.v6Start[i].cmp(a) > 0
+	}) - 1
+	if i >= 0 && a.cmp(l.v6End[i]) <= 0 {
+		return l.v6Ranges[i], true
+	}
+	return Range{}, false
+}
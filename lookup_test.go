@@ -0,0 +1,106 @@
+package range2cidr
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+
+	t.Log("LOOKUP CONTAINS / FIND:")
+
+	sR := []Range{
+		RangeFromPrefix(netip.MustParsePrefix("10.0.0.0/24")),
+		RangeFromPrefix(netip.MustParsePrefix("10.0.2.0/24")),
+		RangeFromPrefix(netip.MustParsePrefix("2001:db8::/32")),
+	}
+	l := NewLookup(sR)
+
+	cases := []struct {
+		addr string
+		want bool
+	}{
+		{"10.0.0.1", true},
+		{"10.0.1.1", false},
+		{"10.0.2.1", true},
+		{"2001:db8::1", true},
+		{"2001:db9::1", false},
+	}
+
+	for _, c := range cases {
+		got := l.Contains(netip.MustParseAddr(c.addr))
+		if got != c.want {
+			t.Log("\t", cmsg(false, "MISMATCH"), c.addr, got, c.want)
+			t.FailNow()
+		}
+		t.Log("\t", cmsg(true, "OK"), c.addr)
+	}
+
+	if !l.ContainsPrefix(netip.MustParsePrefix("10.0.0.0/25")) {
+		t.Fatal("expected 10.0.0.0/25 to be contained")
+	}
+	if l.ContainsPrefix(netip.MustParsePrefix("10.0.0.0/23")) {
+		t.Fatal("10.0.0.0/23 spans the 10.0.1.0/24 gap and should not be contained")
+	}
+
+	rng, ok := l.Find(netip.MustParseAddr("10.0.2.5"))
+	if !ok || rng.Deaggregate()[0].String() != "10.0.2.0/24" {
+		t.Fatalf("expected Find to return 10.0.2.0/24, got %+v ok=%v", rng, ok)
+	}
+
+	t.Log("\t", cmsg(true, "SUCCESS!"))
+}
+
+func buildLookupFixture(n int) (*Lookup, []netip.Prefix) {
+	pfxs := make([]netip.Prefix, n)
+	sR := make([]Range, n)
+	for i := 0; i < n; i++ {
+		a := uint32(i) * 256
+		pfxs[i] = netip.PrefixFrom(Uint32ToV4(a), 24)
+		sR[i] = RangeFromPrefix(pfxs[i])
+	}
+	return NewLookup(sR), pfxs
+}
+
+func BenchmarkLookupContains(b *testing.B) {
+	l, pfxs := buildLookupFixture(10_000)
+	addr := pfxs[len(pfxs)/2].Addr()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Contains(addr)
+	}
+}
+
+func BenchmarkLinearScanContains(b *testing.B) {
+	_, pfxs := buildLookupFixture(10_000)
+	sR := make([]Range, len(pfxs))
+	for i, p := range pfxs {
+		sR[i] = RangeFromPrefix(p)
+	}
+	addr := pfxs[len(pfxs)/2].Addr()
+	bs := addr.As16()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, r := range sR {
+			if Cmp(&r.A, &bs) <= 0 && Cmp(&bs, &r.Z) <= 0 {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkPrefixContainsLoop(b *testing.B) {
+	_, pfxs := buildLookupFixture(10_000)
+	addr := pfxs[len(pfxs)/2].Addr()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, p := range pfxs {
+			if p.Contains(addr) {
+				break
+			}
+		}
+	}
+}
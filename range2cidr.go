@@ -36,34 +36,26 @@ func (v Range) Deaggregate() []netip.Prefix {
 // Expects ipLo & ipHi to be slices of the same size, where ipLo <= ipHi.
 func splitIntoPrefixes(bsIpLo, bsIpHi [16]byte) (RET []netip.Prefix) {
 
-	// bigLo <= bigHi
-	for Cmp(&bsIpLo, &bsIpHi) != 1 {
-
-		nStep := uint(0)
-		var lowOrderMask [16]byte
-
-		for !GetBit(&bsIpLo, nStep) {
+	lo := from16(bsIpLo)
+	hi := from16(bsIpHi)
 
-			// grow mask from low order for each nStep
-			SetBit(&lowOrderMask, nStep, true)
+	// bigLo <= bigHi
+	for lo.cmp(hi) != 1 {
 
-			// OR mask with bigLo address
-			NEXT := bsIpLo
-			for i := range NEXT {
-				NEXT[i] |= lowOrderMask[i]
-			}
+		// largest block lo is aligned to
+		nStep := lo.trailingZeros()
 
-			// stop when next > bigHi
-			if Cmp(&NEXT, &bsIpHi) == 1 {
-				break
+		// clamp to the largest block that still fits under hi:
+		// (2^nStep - 1) <= (hi - lo), i.e. nStep <= log2(hi-lo+1)
+		size, _ := hi.sub(lo)
+		if szPlus1, overflow := size.add(addr128{lo: 1}); overflow == 0 {
+			if fl := uint(szPlus1.bitLen() - 1); fl < nStep {
+				nStep = fl
 			}
-
-			nStep += 1
 		}
 
-		// convert calculated base addr back into a netip.Addr,
-		// re-using low address slice as an intermediary
-		addr := netip.AddrFrom16(bsIpLo)
+		// convert calculated base addr back into a netip.Addr
+		addr := netip.AddrFrom16(lo.to16())
 		nMaskBits := (16 * 8) - int(nStep)
 		if addr.Is4In6() {
 			addr = addr.Unmap()
@@ -72,9 +64,14 @@ func splitIntoPrefixes(bsIpLo, bsIpHi [16]byte) (RET []netip.Prefix) {
 		prfx := netip.PrefixFrom(addr, nMaskBits)
 		RET = append(RET, prfx)
 
-		var tmp [16]byte
-		SetBit(&tmp, nStep, true)
-		bsIpLo, _ = Add(&bsIpLo, &tmp)
+		// block covers the whole remaining address space; nothing left to step to
+		if nStep >= 128 {
+			break
+		}
+
+		var block addr128
+		block = block.setBit(nStep, true)
+		lo, _ = lo.add(block)
 	}
 
 	return
@@ -98,9 +95,10 @@ func Aggregate(sR []Range) []Range {
 		return Cmp(&a.A, &b.A)
 	})
 
-	var one [16]byte
-	SetBit(&one, 0, true)
+	one := addr128{lo: 1}
 	j := 0
+	aj := from16(sR[0].A)
+	zj := from16(sR[0].Z)
 	for i := 1; i < len(sR); i += 1 {
 
 		/*
@@ -122,16 +120,18 @@ func Aggregate(sR []Range) []Range {
 			X in [A,Z+1]: ret [A, max(Y,Z)]
 		*/
 
+		ai := from16(sR[i].A)
+		zi := from16(sR[i].Z)
+
 		// sub-range & intersection
-		cmpAA := Cmp(&sR[i].A, &sR[j].A)
-		nextZ, _ := Add(&sR[j].Z, &one)
-		cmpAZ := Cmp(&sR[i].A, &nextZ)
+		nextZj, _ := zj.add(one)
 
 		// X in [A,Z]
-		if (cmpAA >= 0) && (cmpAZ <= 0) {
+		if (ai.cmp(aj) >= 0) && (ai.cmp(nextZj) <= 0) {
 			// max(Y,Z)
-			if Cmp(&sR[i].Z, &sR[j].Z) > 0 {
-				sR[j].Z = sR[i].Z
+			if zi.cmp(zj) > 0 {
+				zj = zi
+				sR[j].Z = zi.to16()
 			}
 			continue
 		}
@@ -139,6 +139,8 @@ func Aggregate(sR []Range) []Range {
 		// otherwise append to stack, unchanged
 		j += 1
 		sR[j] = sR[i]
+		aj = ai
+		zj = zi
 	}
 
 	return sR[:j+1]
@@ -176,53 +178,27 @@ func ToBig(addr netip.Addr) *big.Int {
 
 // compare two IPs.
 // the result will be 0 if (a == b), -1 if (a < b), and +1 if (a > b).
+//
+// a thin wrapper over the addr128 hot path; kept for callers already
+// using the [16]byte form.
 func Cmp(a, b *[16]byte) int {
-	for i := 0; i < 16; i++ {
-		d := int(a[i]) - int(b[i])
-		if d < 0 {
-			return -1
-		} else if d > 0 {
-			return 1
-		}
-	}
-	return 0
+	return from16(*a).cmp(from16(*b))
 }
 
 // returns sum of a and b, and carry bit.
 func Add(a, b *[16]byte) (ret [16]byte, carry int) {
-	for i := 15; i >= 0; i-- {
-		v := int(a[i]) + int(b[i]) + carry
-		if v < 256 {
-			ret[i] = byte(v)
-			carry = 0
-		} else {
-			ret[i] = byte(v & 0xFF)
-			carry = 1
-		}
-	}
-	return ret, carry
+	sum, c := from16(*a).add(from16(*b))
+	return sum.to16(), int(c)
 }
 
 // get n-th bit in a.  returns true if set, false if not.
 func GetBit(a *[16]byte, n uint) bool {
-	byteIx := 15 - int(n>>3)
-	if byteIx < 0 {
-		return false
-	}
-	return (a[byteIx] & (1 << (n & 0b111))) != 0
+	return from16(*a).bit(n)
 }
 
 // set/clear n-th bit in a.
 func SetBit(a *[16]byte, n uint, bSet bool) {
-	byteIx := 15 - int(n>>3)
-	if byteIx < 0 {
-		return
-	}
-	if bSet {
-		a[byteIx] |= 1 << (n & 0b111)
-	} else {
-		a[byteIx] &^= 1 << (n & 0b111)
-	}
+	*a = from16(*a).setBit(n, bSet).to16()
 }
 
 type Range struct {
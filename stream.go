@@ -0,0 +1,361 @@
+package range2cidr
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+	"iter"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+)
+
+// StreamOutput selects the format AggregateStream writes its results in.
+type StreamOutput int
+
+const (
+	// OutputCIDR writes each aggregated range as one or more covering
+	// CIDR prefixes (via Deaggregate), one per line.
+	OutputCIDR StreamOutput = iota
+	// OutputRange writes each aggregated range as "first-last", one per
+	// line.
+	OutputRange
+)
+
+// StreamOptions configures AggregateStream.
+type StreamOptions struct {
+	// Output selects the line format written to the output.
+	Output StreamOutput
+	// ChunkSize caps how many Range values are held in memory at once
+	// before being sorted and spilled to a temp file.  Zero uses a
+	// default of 1,000,000.
+	ChunkSize int
+}
+
+const defaultStreamChunkSize = 1_000_000
+
+// AggregateStream reads one address, range, or prefix per line from r,
+// and writes the minimal set of covering ranges (or CIDRs, per
+// opts.Output) to w.
+//
+// Unlike Aggregate, the input is never held in memory all at once: it is
+// split into sorted chunks spilled to temp files, then combined with a
+// k-way merge that feeds the same single-pass aggregation logic
+// Aggregate uses internally.
+func AggregateStream(r io.Reader, w io.Writer, opts StreamOptions) error {
+
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultStreamChunkSize
+	}
+
+	var tmpFiles []*os.File
+	defer func() {
+		for _, f := range tmpFiles {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	buf := make([]Range, 0, chunkSize)
+	flush := func() error {
+		if len(buf) == 0 {
+			return nil
+		}
+		f, err := spillChunk(buf)
+		if err != nil {
+			return err
+		}
+		tmpFiles = append(tmpFiles, f)
+		buf = buf[:0]
+		return nil
+	}
+
+	pScan := bufio.NewScanner(r)
+	nLine := 0
+	for pScan.Scan() {
+
+		nLine += 1
+		line := strings.TrimSpace(pScan.Text())
+		if len(line) == 0 {
+			continue
+		}
+
+		rng, err := parseRangeLine(line)
+		if err != nil {
+			return fmt.Errorf("failed to parse line %d (%q): %w", nLine, line, err)
+		}
+
+		buf = append(buf, rng)
+		if len(buf) >= chunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := pScan.Err(); err != nil {
+		return err
+	}
+
+	var merged iter.Seq[Range]
+	var mergeErr error
+	if len(tmpFiles) == 0 {
+		// everything fit in memory; no need to round-trip through disk.
+		slices.SortFunc(buf, func(a, b Range) int {
+			return Cmp(&a.A, &b.A)
+		})
+		merged = slices.Values(buf)
+	} else {
+		if err := flush(); err != nil {
+			return err
+		}
+		readers := make([]*chunkReader, len(tmpFiles))
+		for i, f := range tmpFiles {
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			readers[i] = newChunkReader(f)
+		}
+		merged = mergeSortedChunks(readers, &mergeErr)
+	}
+
+	pOut := bufio.NewWriter(w)
+	for rng := range AggregateSortedRanges(merged) {
+		if err := writeRange(pOut, rng, opts.Output); err != nil {
+			return err
+		}
+	}
+	// mergeErr is only ever set by mergeSortedChunks, which stops
+	// yielding as soon as a chunk read fails, so the loop above always
+	// drains before it's safe to check.
+	if mergeErr != nil {
+		return fmt.Errorf("failed reading a spilled chunk: %w", mergeErr)
+	}
+	return pOut.Flush()
+}
+
+// AggregateSortedRanges merges a sequence of Range values that is
+// already sorted ascending by A (as Aggregate itself sorts its input)
+// into the minimal set of disjoint, covering ranges.  It holds only the
+// most recently merged range at a time, so it can run over a sequence
+// backed by a k-way merge of on-disk chunks.
+func AggregateSortedRanges(seq iter.Seq[Range]) iter.Seq[Range] {
+	return func(yield func(Range) bool) {
+
+		one := addr128{lo: 1}
+		var cur Range
+		haveCur := false
+
+		for r := range seq {
+			r.Normalize()
+
+			if !haveCur {
+				cur = r
+				haveCur = true
+				continue
+			}
+
+			aCur, zCur := from16(cur.A), from16(cur.Z)
+			aR, zR := from16(r.A), from16(r.Z)
+			nextZCur, _ := zCur.add(one)
+
+			// X in [A,Z+1]
+			if aR.cmp(aCur) >= 0 && aR.cmp(nextZCur) <= 0 {
+				if zR.cmp(zCur) > 0 {
+					cur.Z = r.Z
+				}
+				continue
+			}
+
+			if !yield(cur) {
+				return
+			}
+			cur = r
+		}
+
+		if haveCur {
+			yield(cur)
+		}
+	}
+}
+
+// parseRangeLine parses a single line of stream input, accepting a bare
+// address ("a.b.c.d"), a CIDR prefix ("a.b.c.d/n"), or a dashed range
+// ("a.b.c.d-e.f.g.h").
+func parseRangeLine(line string) (Range, error) {
+
+	if idx := strings.IndexByte(line, '/'); idx >= 0 {
+		pfx, err := netip.ParsePrefix(line)
+		if err != nil {
+			return Range{}, err
+		}
+		return RangeFromPrefix(pfx), nil
+	}
+
+	if idx := strings.IndexByte(line, '-'); idx >= 0 {
+		lo, err := netip.ParseAddr(strings.TrimSpace(line[:idx]))
+		if err != nil {
+			return Range{}, err
+		}
+		hi, err := netip.ParseAddr(strings.TrimSpace(line[idx+1:]))
+		if err != nil {
+			return Range{}, err
+		}
+		return RangeFromAddrs(lo, hi), nil
+	}
+
+	addr, err := netip.ParseAddr(line)
+	if err != nil {
+		return Range{}, err
+	}
+	return RangeFromAddrs(addr, addr), nil
+}
+
+// writeRange writes a single aggregated range to w in the requested
+// output format.
+func writeRange(w *bufio.Writer, rng Range, out StreamOutput) error {
+
+	if out == OutputRange {
+		loAddr := netip.AddrFrom16(rng.A)
+		hiAddr := netip.AddrFrom16(rng.Z)
+		if loAddr.Is4In6() {
+			loAddr, hiAddr = loAddr.Unmap(), hiAddr.Unmap()
+		}
+		_, err := fmt.Fprintf(w, "%s-%s\n", loAddr, hiAddr)
+		return err
+	}
+
+	for _, pfx := range rng.Deaggregate() {
+		if _, err := fmt.Fprintln(w, pfx.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// spillChunk sorts a chunk of ranges by A and writes it to a new temp
+// file as consecutive 32-byte (A, Z) records.
+func spillChunk(chunk []Range) (*os.File, error) {
+
+	slices.SortFunc(chunk, func(a, b Range) int {
+		return Cmp(&a.A, &b.A)
+	})
+
+	f, err := os.CreateTemp("", "range2cidr-chunk-*")
+	if err != nil {
+		return nil, err
+	}
+
+	pOut := bufio.NewWriter(f)
+	var rec [32]byte
+	for _, r := range chunk {
+		copy(rec[:16], r.A[:])
+		copy(rec[16:], r.Z[:])
+		if _, err := pOut.Write(rec[:]); err != nil {
+			f.Close()
+			os.Remove(f.Name())
+			return nil, err
+		}
+	}
+	if err := pOut.Flush(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// chunkReader reads back the 32-byte (A, Z) records written by
+// spillChunk.
+type chunkReader struct {
+	r *bufio.Reader
+}
+
+func newChunkReader(f *os.File) *chunkReader {
+	return &chunkReader{r: bufio.NewReader(f)}
+}
+
+func (c *chunkReader) next() (Range, bool, error) {
+	var rec [32]byte
+	if _, err := io.ReadFull(c.r, rec[:]); err != nil {
+		if err == io.EOF {
+			return Range{}, false, nil
+		}
+		return Range{}, false, err
+	}
+	var rng Range
+	copy(rng.A[:], rec[:16])
+	copy(rng.Z[:], rec[16:])
+	return rng, true, nil
+}
+
+// mergeItem is one live element of the k-way merge heap.
+type mergeItem struct {
+	rng Range
+	src int
+}
+
+type mergeHeap []mergeItem
+
+func (h mergeHeap) Len() int            { return len(h) }
+func (h mergeHeap) Less(i, j int) bool  { return Cmp(&h[i].rng.A, &h[j].rng.A) < 0 }
+func (h mergeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x interface{}) { *h = append(*h, x.(mergeItem)) }
+func (h *mergeHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// mergeSortedChunks performs a k-way heap-merge over readers, each of
+// which must already yield Range values sorted ascending by A, producing
+// one overall ascending-by-A sequence.
+//
+// A read error from any reader (a truncated temp file, a disk I/O
+// failure, ...) is written to *errOut and the merge stops yielding
+// immediately, rather than being treated as that chunk having simply run
+// out of records — silently dropping a live chunk would hand back a
+// short, wrong aggregation with no indication anything went wrong.
+func mergeSortedChunks(readers []*chunkReader, errOut *error) iter.Seq[Range] {
+	return func(yield func(Range) bool) {
+
+		var h mergeHeap
+		for i, rdr := range readers {
+			r, ok, err := rdr.next()
+			if err != nil {
+				*errOut = err
+				return
+			}
+			if !ok {
+				continue
+			}
+			h = append(h, mergeItem{rng: r, src: i})
+		}
+		heap.Init(&h)
+
+		for h.Len() > 0 {
+			top := heap.Pop(&h).(mergeItem)
+			if !yield(top.rng) {
+				return
+			}
+			r, ok, err := readers[top.src].next()
+			if err != nil {
+				*errOut = err
+				return
+			}
+			if ok {
+				heap.Push(&h, mergeItem{rng: r, src: top.src})
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package range2cidr
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAggregateStream(t *testing.T) {
+
+	t.Log("AGGREGATE STREAM: in-memory + parse errors:")
+
+	in := strings.NewReader("10.0.0.5-10.0.0.20\n10.0.0.21-10.0.0.30\n")
+	var out bytes.Buffer
+	if err := AggregateStream(in, &out, StreamOptions{Output: OutputRange}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "10.0.0.5-10.0.0.30\n"
+	if out.String() != want {
+		t.Fatalf("got %q want %q", out.String(), want)
+	}
+	t.Log("\t", cmsg(true, "OK"), "range output merges adjacent dashed ranges")
+
+	bad := strings.NewReader("not-an-address\n")
+	if err := AggregateStream(bad, &out, StreamOptions{Output: OutputCIDR}); err == nil {
+		t.Fatal("expected a parse error for an unrecognized line")
+	}
+	t.Log("\t", cmsg(true, "OK"), "unparsable line surfaces an error")
+
+	t.Log("\t", cmsg(true, "SUCCESS!"))
+}
+
+func TestAggregateStreamSpillsToTempFiles(t *testing.T) {
+
+	t.Log("AGGREGATE STREAM: forces multiple chunk spills:")
+
+	var sb strings.Builder
+	const n = 2000
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "10.%d.%d.0/24\n", i/256, i%256)
+	}
+
+	var out bytes.Buffer
+	err := AggregateStream(strings.NewReader(sb.String()), &out, StreamOptions{
+		Output:    OutputCIDR,
+		ChunkSize: 64, // forces many small on-disk chunks and a k-way merge
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(got) == 0 || got[0] != "10.0.0.0/14" {
+		t.Fatalf("expected aggregation to start with 10.0.0.0/14, got %v", got)
+	}
+
+	t.Log("\t", cmsg(true, "SUCCESS!"))
+}
+
+// writeChunkFile spills ranges to a temp file via spillChunk, optionally
+// truncating the last few bytes to simulate a corrupted/truncated spill.
+func writeChunkFile(t *testing.T, ranges []Range, truncateLastRecord bool) *os.File {
+	t.Helper()
+
+	f, err := spillChunk(append([]Range(nil), ranges...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if truncateLastRecord {
+		info, err := f.Stat()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Truncate(info.Size() - 10); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return f
+}
+
+func TestMergeSortedChunksPropagatesReadError(t *testing.T) {
+
+	t.Log("MERGE SORTED CHUNKS: a truncated spill surfaces an error, not a silent short result:")
+
+	good := writeChunkFile(t, []Range{
+		RangeFromPrefix(netip.MustParsePrefix("10.0.0.0/24")),
+		RangeFromPrefix(netip.MustParsePrefix("10.0.1.0/24")),
+	}, false)
+	defer os.Remove(good.Name())
+
+	bad := writeChunkFile(t, []Range{
+		RangeFromPrefix(netip.MustParsePrefix("192.168.0.0/24")),
+		RangeFromPrefix(netip.MustParsePrefix("192.168.1.0/24")),
+	}, true) // truncated mid-record
+	defer os.Remove(bad.Name())
+
+	readers := []*chunkReader{newChunkReader(good), newChunkReader(bad)}
+
+	var mergeErr error
+	mergeSortedChunks(readers, &mergeErr)(func(r Range) bool { return true })
+
+	if mergeErr == nil {
+		t.Fatal("expected a read error from the truncated chunk to propagate")
+	}
+
+	t.Log("\t", cmsg(true, "SUCCESS!"))
+}